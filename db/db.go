@@ -0,0 +1,136 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package db contains functions for accessing the Firebase Realtime Database.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/transport"
+
+	"firebase.google.com/go/internal"
+
+	"golang.org/x/net/context"
+)
+
+// Client is the interface for the Firebase Realtime Database service.
+type Client struct {
+	clientMu sync.RWMutex
+	client   *internal.HTTPClient
+	dbURL    string
+	opts     []option.ClientOption
+
+	// defaultTxnOptions are applied to every Transaction call on Refs obtained from this
+	// Client, unless overridden by options passed to Transaction directly.
+	defaultTxnOptions []TransactionOption
+}
+
+// NewClient creates a new instance of the Firebase Database Client.
+//
+// This function can only be invoked from within the SDK. Client applications should access the
+// the database service through firebase.App.
+func NewClient(ctx context.Context, c *internal.DatabaseConfig) (*Client, error) {
+	if c.URL == "" {
+		return nil, errors.New("database url is required to access the realtime database")
+	}
+
+	hc, _, err := transport.NewHTTPClient(ctx, c.Opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		client: &internal.HTTPClient{Client: hc},
+		dbURL:  strings.TrimRight(c.URL, "/"),
+		opts:   c.Opts,
+	}, nil
+}
+
+// refreshAuth rebuilds the Client's underlying HTTP client from scratch, forcing a fresh token to
+// be fetched from the credentials' token source.
+//
+// This is stronger than relying on the token source's own expiry-based refresh: it is needed when
+// a token is invalidated server-side (signaled by an auth_revoked Listen event) before it was due
+// to expire naturally, in which case the old token source would just keep handing back the same
+// now-invalid token.
+func (c *Client) refreshAuth(ctx context.Context) error {
+	hc, _, err := transport.NewHTTPClient(ctx, c.opts...)
+	if err != nil {
+		return err
+	}
+
+	c.clientMu.Lock()
+	c.client = &internal.HTTPClient{Client: hc}
+	c.clientMu.Unlock()
+	return nil
+}
+
+// httpClient returns the Client's current underlying HTTP client, honoring any refreshAuth calls
+// that may have replaced it concurrently.
+func (c *Client) httpClient() *internal.HTTPClient {
+	c.clientMu.RLock()
+	defer c.clientMu.RUnlock()
+	return c.client
+}
+
+// NewRef returns a new database reference representing the node at the specified path.
+func (c *Client) NewRef(path string) *Ref {
+	segs := parsePath(path)
+	var key string
+	if len(segs) > 0 {
+		key = segs[len(segs)-1]
+	}
+	return &Ref{
+		Key:    key,
+		Path:   "/" + strings.Join(segs, "/"),
+		segs:   segs,
+		client: c,
+	}
+}
+
+// parsePath splits a slash-separated database path into its individual segments, ignoring
+// leading, trailing, or repeated slashes.
+func parsePath(path string) []string {
+	var segs []string
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
+
+// request models a single REST call against the Realtime Database, before it is resolved to a
+// fully-qualified internal.Request by Client.send.
+type request struct {
+	Method string
+	Path   string
+	Body   interface{}
+	Opts   []internal.HTTPOption
+}
+
+func (c *Client) send(ctx context.Context, r *request) (*internal.Response, error) {
+	req := &internal.Request{
+		Method: r.Method,
+		URL:    fmt.Sprintf("%s%s.json", c.dbURL, r.Path),
+		Body:   r.Body,
+		Opts:   r.Opts,
+	}
+	return c.httpClient().Do(ctx, req)
+}
@@ -0,0 +1,46 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"golang.org/x/oauth2"
+
+	"google.golang.org/api/option"
+
+	"firebase.google.com/go/internal"
+
+	"golang.org/x/net/context"
+)
+
+var testTokenSource = &internal.MockTokenSource{AccessToken: "test-token"}
+
+// newTestClient creates a Client pointed at the given URL (typically an httptest.Server), using a
+// mock credential shared by all db package tests.
+func newTestClient(ctx context.Context, url string) (*Client, error) {
+	return newTestClientWithTokenSource(ctx, url, testTokenSource)
+}
+
+// newTestClientWithTokenSource creates a Client pointed at the given URL, authenticated with the
+// given token source. Tests that need to observe the effect of a token change (e.g. a forced
+// refresh after auth_revoked) construct their own token source and use this instead of
+// newTestClient.
+func newTestClientWithTokenSource(ctx context.Context, url string, ts oauth2.TokenSource) (*Client, error) {
+	return NewClient(ctx, &internal.DatabaseConfig{
+		URL: url,
+		Opts: []option.ClientOption{
+			option.WithTokenSource(ts),
+		},
+	})
+}
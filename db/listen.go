@@ -0,0 +1,272 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// EventType identifies the kind of change a streamed Event represents.
+type EventType string
+
+const (
+	// EventTypePut indicates that the data at Event.Path was replaced. If Event.Data is the JSON
+	// literal null, the data at that location was deleted.
+	EventTypePut EventType = "put"
+
+	// EventTypePatch indicates that the children of Event.Path were updated in place.
+	EventTypePatch EventType = "patch"
+
+	// EventTypeCancel indicates that the server closed the stream, typically because the
+	// applicable security rules no longer permit the read. It is not delivered on Events: the
+	// Subscription reports it as an error on Errors and then reconnects.
+	EventTypeCancel EventType = "cancel"
+
+	// EventTypeAuthRevoked indicates that the credential used to establish the stream is no
+	// longer valid. A Subscription reconnects automatically when this happens.
+	EventTypeAuthRevoked EventType = "auth_revoked"
+
+	eventTypeKeepAlive EventType = "keep-alive"
+)
+
+const (
+	listenInitialDelay = 1 * time.Second
+	listenMaxDelay     = 30 * time.Second
+	listenMultiplier   = 2
+)
+
+// Event represents a single realtime update delivered by a Subscription.
+type Event struct {
+	// Type is the kind of change this Event represents.
+	Type EventType
+
+	// Path is the location of the change, relative to the Ref the Subscription was created from.
+	Path string
+
+	// Data is the raw JSON payload that accompanied the event, if any.
+	Data json.RawMessage
+}
+
+// Unmarshal decodes the event's Data into v, so callers don't need to hand-parse the raw
+// interface{} tree themselves. See https://golang.org/pkg/encoding/json/#Unmarshal for the
+// requirements this places on v.
+func (e *Event) Unmarshal(v interface{}) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// Subscription streams realtime updates for the database location it was created from.
+//
+// Events are delivered on the Events channel. Any error encountered while connecting or
+// reconnecting is delivered on the buffered Errors channel on a best-effort basis; a Subscription
+// keeps retrying after an error whether or not it was read, so callers that only care about the
+// happy path can safely ignore Errors. Close must be called once the caller is done with the
+// Subscription, to release the underlying connection.
+type Subscription struct {
+	Events <-chan Event
+	Errors <-chan error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Close terminates the Subscription and waits for its background goroutine to exit. It is safe to
+// call Close more than once.
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// Listen starts streaming realtime updates for the data at this location.
+//
+// Firebase delivers updates over a long-lived `text/event-stream` connection. Listen reconnects
+// automatically, with exponential backoff, whenever that connection drops. An `auth_revoked` event
+// additionally forces a fresh token to be fetched before reconnecting, since the revoked
+// credential would otherwise just be rejected again. The returned Subscription must be closed once
+// the caller is done with it.
+func (r *Ref) Listen(ctx context.Context) (*Subscription, error) {
+	cctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+
+	go r.listenLoop(cctx, events, errs, done)
+
+	return &Subscription{
+		Events: events,
+		Errors: errs,
+		cancel: cancel,
+		done:   done,
+	}, nil
+}
+
+func (r *Ref) listenLoop(ctx context.Context, events chan<- Event, errs chan<- error, done chan struct{}) {
+	defer close(done)
+	defer close(events)
+	defer close(errs)
+
+	delay := listenInitialDelay
+	for {
+		connected := time.Now()
+		err := r.listenOnce(ctx, events)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Best-effort delivery: if the caller isn't draining Errors (or a previous error is
+			// still sitting in the buffer), drop this one rather than blocking the reconnect loop.
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+
+		// A connection that stayed up for a while is a sign the failure was transient; don't let
+		// short-lived hiccups ratchet the delay up indefinitely.
+		if time.Since(connected) > delay {
+			delay = listenInitialDelay
+		}
+
+		select {
+		case <-time.After(jitter(delay)):
+		case <-ctx.Done():
+			return
+		}
+		delay = time.Duration(float64(delay) * listenMultiplier)
+		if delay > listenMaxDelay {
+			delay = listenMaxDelay
+		}
+	}
+}
+
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// listenOnce opens a single SSE connection and delivers events until the connection is closed,
+// the server cancels it, or an error occurs. A nil return means the caller should reconnect; the
+// caller is responsible for backing off before doing so.
+func (r *Ref) listenOnce(ctx context.Context, events chan<- Event) error {
+	resp, err := r.client.sendStream(ctx, r.Path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("error connecting to %q: %s", r.Path, string(body))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for {
+		se, err := readSSEEvent(scanner)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch EventType(se.name) {
+		case "":
+			continue
+		case eventTypeKeepAlive:
+			continue
+		case EventTypeCancel:
+			return fmt.Errorf("listen on %q was canceled by the server", r.Path)
+		case EventTypeAuthRevoked:
+			// The credential used for this connection was revoked server-side, possibly before
+			// it was due to expire naturally. Force a new token to be fetched before the caller
+			// reconnects; otherwise the reused token source would just hand back the same
+			// now-invalid token and the server would immediately send another auth_revoked.
+			return r.client.refreshAuth(ctx)
+		}
+
+		var payload struct {
+			Path string          `json:"path"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(se.data), &payload); err != nil {
+			return err
+		}
+
+		e := Event{Type: EventType(se.name), Path: payload.Path, Data: payload.Data}
+		select {
+		case events <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sseEvent is a single, fully-buffered `text/event-stream` frame.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSEEvent reads lines from scanner until it has accumulated one complete SSE frame
+// (terminated by a blank line), per https://html.spec.whatwg.org/multipage/server-sent-events.html.
+func readSSEEvent(scanner *bufio.Scanner) (*sseEvent, error) {
+	var name string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if name == "" && len(data) == 0 {
+				continue
+			}
+			return &sseEvent{name: name, data: strings.Join(data, "\n")}, nil
+		}
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// sendStream issues a GET request for path as a `text/event-stream`, bypassing the body-buffering
+// internal.Response path used by send, since the response body here is read incrementally for as
+// long as the stream stays open.
+func (c *Client) sendStream(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s.json", c.dbURL, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	return c.httpClient().Client.Do(req)
+}
@@ -0,0 +1,223 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"golang.org/x/net/context"
+)
+
+// countingTokenSource hands out a new, distinct access token every time it is asked for one, so
+// tests can tell whether a client actually fetched a fresh token rather than reusing a cached one.
+type countingTokenSource struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.count++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", ts.count)}, nil
+}
+
+func TestReadSSEEventMultilineData(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("event: put\ndata: line1\ndata: line2\n\n"))
+	se, err := readSSEEvent(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if se.name != "put" || se.data != "line1\nline2" {
+		t.Errorf("readSSEEvent() = %+v; want {name: put, data: line1\\nline2}", se)
+	}
+}
+
+func TestListenOnceDeliversEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != "text/event-stream" {
+			t.Errorf("Accept = %q; want text/event-stream", accept)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: put\ndata: {\"path\":\"/\",\"data\":{\"a\":1}}\n\n")
+		fmt.Fprint(w, "event: keep-alive\ndata: null\n\n")
+		fmt.Fprint(w, "event: patch\ndata: {\"path\":\"/b\",\"data\":2}\n\n")
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	events := make(chan Event, 2)
+	if err := ref.listenOnce(ctx, events); err != nil {
+		t.Fatalf("listenOnce() = %v; want nil", err)
+	}
+	close(events)
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events; want 2 (keep-alive should be filtered out)", len(got))
+	}
+
+	if got[0].Type != EventTypePut || got[0].Path != "/" {
+		t.Errorf("event[0] = %+v; want type=put path=/", got[0])
+	}
+	var v map[string]int
+	if err := got[0].Unmarshal(&v); err != nil || v["a"] != 1 {
+		t.Errorf("Unmarshal(event[0]) = (%v, %v); want a=1", v, err)
+	}
+
+	if got[1].Type != EventTypePatch || got[1].Path != "/b" {
+		t.Errorf("event[1] = %+v; want type=patch path=/b", got[1])
+	}
+}
+
+func TestListenOnceAuthRevokedSignalsReconnect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: auth_revoked\ndata: credential expired\n\n")
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	events := make(chan Event, 1)
+	if err := ref.listenOnce(ctx, events); err != nil {
+		t.Errorf("listenOnce() = %v; want nil (auth_revoked should trigger a silent reconnect)", err)
+	}
+}
+
+func TestListenOnceAuthRevokedRefreshesToken(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: auth_revoked\ndata: credential expired\n\n")
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClientWithTokenSource(ctx, ts.URL, &countingTokenSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	events := make(chan Event, 1)
+	if err := ref.listenOnce(ctx, events); err != nil {
+		t.Fatalf("listenOnce() = %v; want nil", err)
+	}
+	// Simulate the reconnect the listen loop performs after a nil return.
+	if err := ref.listenOnce(ctx, events); err != nil {
+		t.Fatalf("listenOnce() (reconnect) = %v; want nil", err)
+	}
+
+	if len(tokens) != 2 {
+		t.Fatalf("got %d requests; want 2", len(tokens))
+	}
+	if tokens[0] == "" || tokens[1] == "" {
+		t.Fatalf("tokens = %v; want non-empty Authorization headers", tokens)
+	}
+	if tokens[0] == tokens[1] {
+		t.Errorf("Authorization did not change after auth_revoked; both requests used %q", tokens[0])
+	}
+}
+
+func TestListenOnceCancelReturnsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: cancel\ndata: permission_denied\n\n")
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	events := make(chan Event, 1)
+	if err := ref.listenOnce(ctx, events); err == nil {
+		t.Errorf("listenOnce() = nil; want error")
+	}
+}
+
+func TestListenErrorsChannelDoesNotBlockWhenUnread(t *testing.T) {
+	attempt := make(chan struct{}, 8)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt <- struct{}{}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	sub, err := ref.Listen(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Deliberately never read from sub.Errors. If the Errors channel were unbuffered and sent on
+	// with a blocking select, the listen loop would wedge after the very first failure and never
+	// attempt a second connection.
+	select {
+	case <-attempt:
+	case <-time.After(3 * time.Second):
+		t.Fatal("server never received a first connection attempt")
+	}
+	select {
+	case <-attempt:
+	case <-time.After(3 * time.Second):
+		t.Fatal("listen loop appears to have blocked after the first unread error")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"firebase.google.com/go/internal"
+
+	"golang.org/x/net/context"
+)
+
+// Query is used to sort and filter the data at a database location. Queries are created by
+// calling one of the OrderBy... methods on a Ref, and refined by the Start/End/Limit methods
+// below. A Query is immutable; each refining method returns a new Query, leaving the one it was
+// called on untouched, so a base Query can be safely reused to build multiple derived ones.
+type Query struct {
+	ref    *Ref
+	params map[string]interface{}
+	err    error
+}
+
+// OrderByChild returns a Query that orders the data at the current location by the value of the
+// specified child key.
+func (r *Ref) OrderByChild(child string) *Query {
+	if child == "" || child == "$key" || child == "$value" || child == "$priority" {
+		return &Query{ref: r, err: errors.New("child path must be a non-empty, non-sentinel value")}
+	}
+	return newQuery(r, child)
+}
+
+// OrderByKey returns a Query that orders the data at the current location by their keys.
+func (r *Ref) OrderByKey() *Query {
+	return newQuery(r, "$key")
+}
+
+// OrderByValue returns a Query that orders the data at the current location by their value.
+func (r *Ref) OrderByValue() *Query {
+	return newQuery(r, "$value")
+}
+
+// OrderByPriority returns a Query that orders the data at the current location by their priority.
+func (r *Ref) OrderByPriority() *Query {
+	return newQuery(r, "$priority")
+}
+
+func newQuery(r *Ref, orderBy string) *Query {
+	return &Query{
+		ref:    r,
+		params: map[string]interface{}{"orderBy": orderBy},
+	}
+}
+
+// StartAt restricts the Query to only return data that is greater than or equal to the specified
+// value, given the ordering imposed by the OrderBy... method used to create this Query.
+func (q *Query) StartAt(v interface{}) *Query {
+	return q.withParam("startAt", v)
+}
+
+// EndAt restricts the Query to only return data that is less than or equal to the specified
+// value, given the ordering imposed by the OrderBy... method used to create this Query.
+func (q *Query) EndAt(v interface{}) *Query {
+	return q.withParam("endAt", v)
+}
+
+// EqualTo restricts the Query to only return data that is equal to the specified value, given the
+// ordering imposed by the OrderBy... method used to create this Query.
+func (q *Query) EqualTo(v interface{}) *Query {
+	return q.withParam("equalTo", v)
+}
+
+// LimitToFirst restricts the Query to only return the first n items, given the ordering imposed
+// by the OrderBy... method used to create this Query.
+//
+// LimitToFirst cannot be combined with LimitToLast on the same Query.
+func (q *Query) LimitToFirst(n int) *Query {
+	if _, ok := q.params["limitToLast"]; ok {
+		return q.fail(errors.New("LimitToFirst cannot be combined with LimitToLast"))
+	}
+	return q.withParam("limitToFirst", n)
+}
+
+// LimitToLast restricts the Query to only return the last n items, given the ordering imposed by
+// the OrderBy... method used to create this Query.
+//
+// LimitToLast cannot be combined with LimitToFirst on the same Query.
+func (q *Query) LimitToLast(n int) *Query {
+	if _, ok := q.params["limitToFirst"]; ok {
+		return q.fail(errors.New("LimitToLast cannot be combined with LimitToFirst"))
+	}
+	return q.withParam("limitToLast", n)
+}
+
+// WithShallow restricts the data returned by Get to the immediate children of the current
+// location, replacing any nested data with a boolean true.
+func (q *Query) WithShallow(shallow bool) *Query {
+	return q.withParam("shallow", shallow)
+}
+
+// Get executes the Query, and stores the result in the value pointed to by v. See Ref.Get for
+// details on how the result is deserialized into v.
+func (q *Query) Get(ctx context.Context, v interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	var opts []internal.HTTPOption
+	for name, val := range q.params {
+		s, err := queryParamValue(val)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, internal.WithQueryParam(name, s))
+	}
+
+	resp, err := q.ref.send(ctx, "GET", opts...)
+	if err != nil {
+		return err
+	}
+	return resp.Unmarshal(http.StatusOK, v)
+}
+
+func (q *Query) withParam(name string, v interface{}) *Query {
+	nq := q.clone()
+	if nq.err != nil {
+		return nq
+	}
+	nq.params[name] = v
+	return nq
+}
+
+func (q *Query) fail(err error) *Query {
+	nq := q.clone()
+	if nq.err == nil {
+		nq.err = err
+	}
+	return nq
+}
+
+// clone returns a copy of q with its own params map, so that refining a Query (via StartAt,
+// LimitToFirst, etc.) never mutates a Query it was derived from.
+func (q *Query) clone() *Query {
+	params := make(map[string]interface{}, len(q.params))
+	for k, v := range q.params {
+		params[k] = v
+	}
+	return &Query{ref: q.ref, params: params, err: q.err}
+}
+
+// queryParamValue JSON-encodes v for use as a single RTDB REST query parameter value. Firebase's
+// REST API requires that string arguments be quoted (e.g. `orderBy="age"`), while numbers,
+// booleans and null must be passed unquoted. Marshaling v directly satisfies both cases.
+func queryParamValue(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestQueryOrderByChild(t *testing.T) {
+	var tr *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	var v interface{}
+	q := ref.OrderByChild("age").StartAt(10).EndAt(20).LimitToFirst(5)
+	if err := q.Get(ctx, &v); err != nil {
+		t.Fatalf("Get() = %v; want nil", err)
+	}
+
+	want := map[string]string{
+		"orderBy":      `"age"`,
+		"startAt":      "10",
+		"endAt":        "20",
+		"limitToFirst": "5",
+	}
+	if tr == nil {
+		t.Fatal("Request = nil; want non-nil")
+	}
+	for name, wantVal := range want {
+		if got := tr.URL.Query().Get(name); got != wantVal {
+			t.Errorf("param %q = %q; want %q", name, got, wantVal)
+		}
+	}
+}
+
+func TestQuerySentinels(t *testing.T) {
+	ctx := context.Background()
+	client, err := newTestClient(ctx, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	cases := []struct {
+		q    *Query
+		want string
+	}{
+		{ref.OrderByKey(), `"$key"`},
+		{ref.OrderByValue(), `"$value"`},
+		{ref.OrderByPriority(), `"$priority"`},
+	}
+	for _, tc := range cases {
+		s, err := queryParamValue(tc.q.params["orderBy"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != tc.want {
+			t.Errorf("orderBy = %q; want %q", s, tc.want)
+		}
+	}
+}
+
+func TestQueryInvalidOrderByChild(t *testing.T) {
+	ctx := context.Background()
+	client, err := newTestClient(ctx, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	for _, c := range []string{"", "$key", "$value", "$priority"} {
+		if err := ref.OrderByChild(c).Get(ctx, nil); err == nil {
+			t.Errorf("OrderByChild(%q).Get() = nil; want error", c)
+		}
+	}
+}
+
+func TestQueryConflictingLimits(t *testing.T) {
+	ctx := context.Background()
+	client, err := newTestClient(ctx, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	if err := ref.OrderByChild("age").LimitToFirst(10).LimitToLast(5).Get(ctx, nil); err == nil {
+		t.Errorf("Get() = nil; want error")
+	}
+	if err := ref.OrderByChild("age").LimitToLast(5).LimitToFirst(10).Get(ctx, nil); err == nil {
+		t.Errorf("Get() = nil; want error")
+	}
+}
+
+func TestQueryIsImmutable(t *testing.T) {
+	ctx := context.Background()
+	client, err := newTestClient(ctx, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	base := ref.OrderByChild("age")
+	q1 := base.StartAt(10)
+	q2 := base.EndAt(20)
+
+	if _, ok := base.params["startAt"]; ok {
+		t.Errorf("base query was mutated by StartAt()")
+	}
+	if _, ok := base.params["endAt"]; ok {
+		t.Errorf("base query was mutated by EndAt()")
+	}
+	if _, ok := q1.params["endAt"]; ok {
+		t.Errorf("q1 picked up a param set on a sibling query (q2)")
+	}
+	if _, ok := q2.params["startAt"]; ok {
+		t.Errorf("q2 picked up a param set on a sibling query (q1)")
+	}
+}
@@ -16,15 +16,22 @@ package db
 
 import (
 	"fmt"
+	"math/rand"
 	"net/http"
 	"strings"
+	"time"
 
 	"firebase.google.com/go/internal"
 
 	"golang.org/x/net/context"
 )
 
-const txnRetries = 25
+const (
+	defaultTxnMaxRetries   = 25
+	defaultTxnInitialDelay = 50 * time.Millisecond
+	defaultTxnMultiplier   = 2
+	defaultTxnMaxDelay     = 5 * time.Second
+)
 
 // Ref represents a node in the Firebase Realtime Database.
 type Ref struct {
@@ -161,6 +168,81 @@ func (r *Ref) Update(ctx context.Context, v map[string]interface{}) error {
 
 type UpdateFn func(interface{}) (interface{}, error)
 
+// TransactionOption configures the retry behavior of a single Transaction call.
+type TransactionOption func(*txnConfig)
+
+// WithMaxRetries sets the maximum number of write attempts, including the first one, that
+// Transaction makes before giving up and returning a *TransactionAbortedError. The default is 25.
+func WithMaxRetries(n int) TransactionOption {
+	return func(c *txnConfig) {
+		if n > 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+// WithInitialRetryDelay sets the delay before the first retry. The default is 50ms.
+func WithInitialRetryDelay(d time.Duration) TransactionOption {
+	return func(c *txnConfig) {
+		if d > 0 {
+			c.initialDelay = d
+		}
+	}
+}
+
+// WithRetryDelayMultiplier sets the factor applied to the retry delay after each failed attempt.
+// The default is 2.
+func WithRetryDelayMultiplier(m float64) TransactionOption {
+	return func(c *txnConfig) {
+		if m > 0 {
+			c.multiplier = m
+		}
+	}
+}
+
+// WithMaxRetryDelay caps the delay between retries. The default is 5s.
+func WithMaxRetryDelay(d time.Duration) TransactionOption {
+	return func(c *txnConfig) {
+		if d > 0 {
+			c.maxDelay = d
+		}
+	}
+}
+
+type txnConfig struct {
+	maxRetries   int
+	initialDelay time.Duration
+	multiplier   float64
+	maxDelay     time.Duration
+}
+
+func newTxnConfig(opts ...TransactionOption) *txnConfig {
+	c := &txnConfig{
+		maxRetries:   defaultTxnMaxRetries,
+		initialDelay: defaultTxnInitialDelay,
+		multiplier:   defaultTxnMultiplier,
+		maxDelay:     defaultTxnMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// TransactionAbortedError is returned by Transaction when the update function's result could not
+// be committed after exhausting all configured retry attempts.
+type TransactionAbortedError struct {
+	// ETag is the last ETag observed for the location before giving up.
+	ETag string
+
+	// Value is the last value read from the location before giving up.
+	Value interface{}
+}
+
+func (e *TransactionAbortedError) Error() string {
+	return fmt.Sprintf("transaction aborted after failed retries; last observed etag = %q", e.ETag)
+}
+
 // Transaction atomically modifies the data at this location.
 //
 // Unlike a normal Set(), which just overwrites the data regardless of its previous state,
@@ -170,19 +252,28 @@ type UpdateFn func(interface{}) (interface{}, error)
 // This is accomplished by passing an update function which is used to transform the current value
 // of this reference into a new value. If another client writes to this location before the new
 // value is successfully saved, the update function is called again with the new current value, and
-// the write will be retried. In case of repeated failures, this method will retry the transaction up
-// to 25 times before giving up and returning an error.
+// the write will be retried, waiting an exponentially increasing, jittered delay between attempts.
+// The retry policy can be tuned with TransactionOption values passed in opts, or with a
+// Client-wide default set via Client.SetDefaultTransactionOptions; options passed to Transaction
+// directly take precedence. If every attempt fails, Transaction gives up and returns a
+// *TransactionAbortedError describing the last observed state of the location.
 //
 // The update function may also force an early abort by returning an error instead of returning a
 // value.
-func (r *Ref) Transaction(ctx context.Context, fn UpdateFn) error {
+func (r *Ref) Transaction(ctx context.Context, fn UpdateFn, opts ...TransactionOption) error {
+	cfg := newTxnConfig(append(append([]TransactionOption{}, r.client.defaultTxnOptions...), opts...)...)
+
 	var curr interface{}
 	etag, err := r.GetWithETag(ctx, &curr)
 	if err != nil {
 		return err
 	}
 
-	for i := 0; i < txnRetries; i++ {
+	delay := cfg.initialDelay
+	if delay > cfg.maxDelay {
+		delay = cfg.maxDelay
+	}
+	for i := 0; i < cfg.maxRetries; i++ {
 		new, err := fn(curr)
 		if err != nil {
 			return err
@@ -196,8 +287,44 @@ func (r *Ref) Transaction(ctx context.Context, fn UpdateFn) error {
 			return err
 		}
 		etag = resp.Header.Get("ETag")
+
+		if i == cfg.maxRetries-1 {
+			break
+		}
+		if err := sleepWithFullJitter(ctx, delay); err != nil {
+			return err
+		}
+		delay = time.Duration(float64(delay) * cfg.multiplier)
+		if delay > cfg.maxDelay {
+			delay = cfg.maxDelay
+		}
+	}
+	return &TransactionAbortedError{ETag: etag, Value: curr}
+}
+
+// SetDefaultTransactionOptions sets the TransactionOption values applied to every call to
+// Transaction on Refs obtained from this Client, unless overridden by options passed to
+// Transaction directly. This is useful for applying a project-wide retry policy without
+// threading options through every call site.
+func (c *Client) SetDefaultTransactionOptions(opts ...TransactionOption) {
+	c.defaultTxnOptions = opts
+}
+
+// sleepWithFullJitter sleeps for a random duration in [0, d), honoring context cancellation. This
+// implements the "full jitter" backoff strategy recommended in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+func sleepWithFullJitter(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(rand.Float64() * float64(d)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return fmt.Errorf("transaction aborted after failed retries")
 }
 
 // Delete removes this node from the database.
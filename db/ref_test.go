@@ -0,0 +1,169 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestTransactionSucceedsOnFirstAttempt(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Etag", "etag0")
+		if r.Method == "GET" {
+			w.Write([]byte("1"))
+			return
+		}
+		w.Write([]byte("2"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	fn := func(v interface{}) (interface{}, error) {
+		n, _ := v.(float64)
+		return n + 1, nil
+	}
+	if err := ref.Transaction(ctx, fn); err != nil {
+		t.Fatalf("Transaction() = %v; want nil", err)
+	}
+}
+
+func TestTransactionAbortsAfterMaxRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Etag", "etag0")
+		if r.Method == "GET" {
+			w.Write([]byte("1"))
+			return
+		}
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte("1"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := client.NewRef("test")
+
+	fn := func(v interface{}) (interface{}, error) { return v, nil }
+	err = ref.Transaction(
+		ctx, fn,
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMaxRetryDelay(time.Millisecond))
+
+	aborted, ok := err.(*TransactionAbortedError)
+	if !ok {
+		t.Fatalf("Transaction() error type = %T; want *TransactionAbortedError", err)
+	}
+	if aborted.ETag != "etag0" {
+		t.Errorf("ETag = %q; want %q", aborted.ETag, "etag0")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d; want 3", got)
+	}
+}
+
+func TestTransactionUsesClientDefaultOptions(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Etag", "etag0")
+		if r.Method == "GET" {
+			w.Write([]byte("1"))
+			return
+		}
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte("1"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetDefaultTransactionOptions(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMaxRetryDelay(time.Millisecond))
+	ref := client.NewRef("test")
+
+	fn := func(v interface{}) (interface{}, error) { return v, nil }
+	if err := ref.Transaction(ctx, fn); err == nil {
+		t.Fatalf("Transaction() = nil; want error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d; want 2 (client default not applied)", got)
+	}
+}
+
+func TestTransactionCallOptionsOverrideClientDefaults(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Etag", "etag0")
+		if r.Method == "GET" {
+			w.Write([]byte("1"))
+			return
+		}
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte("1"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := newTestClient(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetDefaultTransactionOptions(
+		WithMaxRetries(10),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMaxRetryDelay(time.Millisecond))
+	ref := client.NewRef("test")
+
+	fn := func(v interface{}) (interface{}, error) { return v, nil }
+	err = ref.Transaction(
+		ctx, fn,
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMaxRetryDelay(time.Millisecond))
+	if err == nil {
+		t.Fatalf("Transaction() = nil; want error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d; want 1 (call-level option should override client default)", got)
+	}
+}
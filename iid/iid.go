@@ -18,7 +18,10 @@ package iid
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"sync"
+	"time"
 
 	"google.golang.org/api/transport"
 
@@ -29,18 +32,74 @@ import (
 
 const iidEndpoint = "https://console.firebase.google.com/v1"
 
+// defaultWorkerPoolSize is the number of goroutines DeleteInstanceIDs uses to fan
+// out requests when the client was not configured with WithWorkerPoolSize.
+const defaultWorkerPoolSize = 10
+
+// defaultRetryConfig is the retry policy used when the client was not configured
+// with WithRetryConfig.
+var defaultRetryConfig = &RetryConfig{
+	MaxRetries:   4,
+	InitialDelay: 500 * time.Millisecond,
+	Multiplier:   2,
+	MaxDelay:     8 * time.Second,
+}
+
 // Client is the interface for the Firebase instance ID service.
 type Client struct {
 	endpoint string
 	client   *internal.HTTPClient
 	project  string
+	workers  int
+	retry    *RetryConfig
+}
+
+// ClientOption configures optional behavior on a Client returned by NewClient.
+type ClientOption func(*Client)
+
+// WithWorkerPoolSize sets the number of goroutines DeleteInstanceIDs uses to fan
+// out requests. The default is 10. Values less than 1 are ignored.
+func WithWorkerPoolSize(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithRetryConfig sets the policy used to retry requests that fail with a
+// transient error (HTTP 429 or 5xx). The default policy retries up to 4 times,
+// starting at a 500ms delay, doubling on each attempt, and capping at 8s.
+func WithRetryConfig(rc *RetryConfig) ClientOption {
+	return func(c *Client) {
+		if rc != nil {
+			c.retry = rc
+		}
+	}
+}
+
+// RetryConfig specifies an exponential-backoff-with-jitter policy for retrying
+// requests that fail with a transient error.
+type RetryConfig struct {
+	// MaxRetries is the number of additional attempts made after an initial
+	// failure.
+	MaxRetries int
+
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+
+	// Multiplier scales the delay after each failed attempt.
+	Multiplier float64
+
+	// MaxDelay caps the delay between retries.
+	MaxDelay time.Duration
 }
 
 // NewClient creates a new instance of the Firebase instance ID Client.
 //
 // This function can only be invoked from within the SDK. Client applications should access the
 // the instance ID service through firebase.App.
-func NewClient(ctx context.Context, c *internal.InstanceIDConfig) (*Client, error) {
+func NewClient(ctx context.Context, c *internal.InstanceIDConfig, opts ...ClientOption) (*Client, error) {
 	if c.ProjectID == "" {
 		return nil, errors.New("project id is required to access instance id client")
 	}
@@ -50,11 +109,17 @@ func NewClient(ctx context.Context, c *internal.InstanceIDConfig) (*Client, erro
 		return nil, err
 	}
 
-	return &Client{
+	client := &Client{
 		endpoint: iidEndpoint,
 		client:   &internal.HTTPClient{Client: hc},
 		project:  c.ProjectID,
-	}, nil
+		workers:  defaultWorkerPoolSize,
+		retry:    defaultRetryConfig,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
 }
 
 // DeleteInstanceID deletes an instance ID from Firebase.
@@ -62,17 +127,141 @@ func NewClient(ctx context.Context, c *internal.InstanceIDConfig) (*Client, erro
 // This can be used to delete an instance ID and associated user data from a Firebase project,
 // pursuant to the General Data protection Regulation (GDPR).
 func (c *Client) DeleteInstanceID(ctx context.Context, iid string) error {
+	_, err := c.deleteWithRetry(ctx, iid)
+	return err
+}
+
+// BatchDeleteResult is the outcome of a DeleteInstanceIDs call.
+//
+// Succeeded and NotFound list the instance IDs that were deleted, or that did not
+// exist in the first place, respectively. Errors maps any remaining instance IDs
+// to the error encountered while deleting them.
+type BatchDeleteResult struct {
+	Succeeded []string
+	NotFound  []string
+	Errors    map[string]error
+}
+
+// DeleteInstanceIDs deletes a batch of instance IDs from Firebase.
+//
+// Requests are fanned out across a bounded pool of goroutines (10 by default;
+// configurable via WithWorkerPoolSize), and each request is retried according to
+// the client's retry policy (configurable via WithRetryConfig) when it fails with
+// a transient error. DeleteInstanceIDs tolerates partial failure: it always
+// returns a *BatchDeleteResult describing the outcome of every ID in ids, and
+// only returns a non-nil error if it could not attempt the deletions at all.
+func (c *Client) DeleteInstanceIDs(ctx context.Context, ids []string) (*BatchDeleteResult, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("ids must not be empty")
+	}
+
+	result := &BatchDeleteResult{Errors: make(map[string]error)}
+	var mu sync.Mutex
+
+	workers := c.workers
+	if workers <= 0 {
+		workers = defaultWorkerPoolSize
+	}
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				status, err := c.deleteWithRetry(ctx, id)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					result.Succeeded = append(result.Succeeded, id)
+				case status == http.StatusNotFound:
+					result.NotFound = append(result.NotFound, id)
+				default:
+					result.Errors[id] = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result, nil
+}
+
+// deleteWithRetry issues the delete request for a single instance ID, retrying
+// transient (429 or 5xx) failures according to the client's retry policy. It
+// returns the last observed HTTP status code alongside any error, so callers can
+// distinguish a 404 (instance ID not found) from other failures.
+func (c *Client) deleteWithRetry(ctx context.Context, iid string) (int, error) {
 	if iid == "" {
-		return errors.New("instance id must not be empty")
+		return 0, errors.New("instance id must not be empty")
+	}
+
+	retry := c.retry
+	if retry == nil {
+		retry = defaultRetryConfig
+	}
+
+	delay := retry.InitialDelay
+	if delay > retry.MaxDelay {
+		delay = retry.MaxDelay
 	}
+	for attempt := 0; ; attempt++ {
+		status, err := c.deleteOnce(ctx, iid)
+		if err == nil || !isTransient(status) || attempt >= retry.MaxRetries {
+			return status, err
+		}
+		if werr := waitWithJitter(ctx, delay); werr != nil {
+			return status, werr
+		}
+		delay = time.Duration(float64(delay) * retry.Multiplier)
+		if delay > retry.MaxDelay {
+			delay = retry.MaxDelay
+		}
+	}
+}
 
+// deleteOnce makes a single DELETE request for the given instance ID.
+func (c *Client) deleteOnce(ctx context.Context, iid string) (int, error) {
 	url := fmt.Sprintf("%s/project/%s/instanceId/%s", c.endpoint, c.project, iid)
 	resp, err := c.client.Do(ctx, &internal.Request{Method: "DELETE", URL: url})
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if err := resp.CheckStatus(http.StatusOK); err != nil {
-		return err
+		return resp.Status, err
+	}
+	return resp.Status, nil
+}
+
+// isTransient reports whether an HTTP status code represents an error worth
+// retrying, i.e. rate limiting or a server-side failure.
+func isTransient(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// waitWithJitter sleeps for a random duration in [0, d), honoring context
+// cancellation.
+func waitWithJitter(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(d))))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return nil
-}
\ No newline at end of file
+}
@@ -17,7 +17,10 @@ package iid
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"google.golang.org/api/option"
 
@@ -33,6 +36,10 @@ var testIIDConfig = &internal.InstanceIDConfig{
 	},
 }
 
+// noRetryOption disables retries entirely, so tests that exercise a single failed attempt don't
+// pay for the default backoff schedule.
+var noRetryOption = WithRetryConfig(&RetryConfig{MaxRetries: 0})
+
 func TestNoProjectID(t *testing.T) {
 	client, err := NewClient(context.Background(), &internal.InstanceIDConfig{})
 	if client != nil || err == nil {
@@ -96,7 +103,7 @@ func TestDeleteInstanceIDError(t *testing.T) {
 	defer ts.Close()
 
 	ctx := context.Background()
-	client, err := NewClient(ctx, testIIDConfig)
+	client, err := NewClient(ctx, testIIDConfig, noRetryOption)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -136,4 +143,125 @@ func TestDeleteInstanceIDConnectionError(t *testing.T) {
 		t.Errorf("DeleteInstanceID() = nil; want = error")
 		return
 	}
-}
\ No newline at end of file
+}
+
+func TestDeleteInstanceIDsEmpty(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, testIIDConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := client.DeleteInstanceIDs(ctx, nil); result != nil || err == nil {
+		t.Errorf("DeleteInstanceIDs(nil) = (%v, %v); want = (nil, error)", result, err)
+	}
+}
+
+func TestDeleteInstanceIDs(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[strings.TrimPrefix(r.URL.Path, "/project/test-project/instanceId/")] = true
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, testIIDConfig, WithWorkerPoolSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.endpoint = ts.URL
+
+	ids := []string{"id1", "id2", "id3", "id4", "id5"}
+	result, err := client.DeleteInstanceIDs(ctx, ids)
+	if err != nil {
+		t.Fatalf("DeleteInstanceIDs() error = %v; want nil", err)
+	}
+	if len(result.Succeeded) != len(ids) {
+		t.Errorf("Succeeded = %v; want %d entries", result.Succeeded, len(ids))
+	}
+	if len(result.NotFound) != 0 || len(result.Errors) != 0 {
+		t.Errorf("NotFound = %v, Errors = %v; want both empty", result.NotFound, result.Errors)
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			t.Errorf("instance ID %q was not requested", id)
+		}
+	}
+}
+
+func TestDeleteInstanceIDsPartialFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/project/test-project/instanceId/")
+		w.Header().Set("Content-Type", "application/json")
+		switch id {
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "broken":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, testIIDConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.endpoint = ts.URL
+
+	result, err := client.DeleteInstanceIDs(ctx, []string{"ok", "missing", "broken"})
+	if err != nil {
+		t.Fatalf("DeleteInstanceIDs() error = %v; want nil", err)
+	}
+	if len(result.Succeeded) != 1 || result.Succeeded[0] != "ok" {
+		t.Errorf("Succeeded = %v; want [ok]", result.Succeeded)
+	}
+	if len(result.NotFound) != 1 || result.NotFound[0] != "missing" {
+		t.Errorf("NotFound = %v; want [missing]", result.NotFound)
+	}
+	if _, ok := result.Errors["broken"]; !ok || len(result.Errors) != 1 {
+		t.Errorf("Errors = %v; want single entry for 'broken'", result.Errors)
+	}
+}
+
+func TestDeleteInstanceIDsRetriesTransientErrors(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, testIIDConfig, WithRetryConfig(&RetryConfig{
+		MaxRetries:   5,
+		InitialDelay: time.Millisecond,
+		Multiplier:   1,
+		MaxDelay:     time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.endpoint = ts.URL
+
+	if err := client.DeleteInstanceID(ctx, "test-iid"); err != nil {
+		t.Errorf("DeleteInstanceID() = %v; want nil", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d; want 3", attempts)
+	}
+}